@@ -0,0 +1,109 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/journal"
+	"github.com/gupta-ak/dcos-checks/pkg/eventlog"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	eventsLoggerKind string
+	eventsLogPath    string
+
+	eventsLoggerOnce sync.Once
+	eventsLoggerInst eventlog.Logger
+)
+
+// getEventsLogger lazily builds the Logger selected by --events-logger. It is lazy because the
+// global RootCmd flags are only populated once cobra has parsed args.
+func getEventsLogger() eventlog.Logger {
+	eventsLoggerOnce.Do(func() {
+		logger, err := eventlog.NewLogger(eventsLoggerKind, eventsLogPath)
+		if err != nil {
+			logrus.Warnf("unable to initialize --events-logger=%s, falling back to none: %s", eventsLoggerKind, err)
+			logger = eventlog.NoopLogger{}
+		}
+		eventsLoggerInst = logger
+	})
+
+	return eventsLoggerInst
+}
+
+// instrumentedCheck wraps a DCOSChecker so its Run method reports through logCheckEvent. It is
+// used instead of modifying RunCheck itself, so every caller that builds a DCOSChecker opts in
+// by wrapping it with withEventLogging rather than RunCheck having to know about events at all.
+type instrumentedCheck struct {
+	DCOSChecker
+	id string
+}
+
+// Run implements DCOSChecker, delegating to the wrapped checker and recording the outcome.
+func (c instrumentedCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
+	start := time.Now()
+	msg, status, err := c.DCOSChecker.Run(ctx, cfg)
+
+	logMsg := msg
+	if err != nil {
+		logMsg = err.Error()
+	}
+	logCheckEvent(c.id, status, time.Since(start), logMsg)
+
+	return msg, status, err
+}
+
+// withEventLogging wraps c so that every completed run is recorded through the configured
+// --events-logger backend, timing the run itself.
+func withEventLogging(c DCOSChecker) DCOSChecker {
+	return instrumentedCheck{DCOSChecker: c, id: c.ID()}
+}
+
+// logCheckEvent records the outcome of a check through the configured --events-logger backend.
+// instrumentedCheck.Run calls this after every wrapped check run, so this is the one place that maps
+// internal check statuses to a journal priority.
+func logCheckEvent(id string, status int, duration time.Duration, message string) {
+	priority := journal.PriInfo
+	switch status {
+	case statusWarning:
+		priority = journal.PriWarning
+	case statusUnknown:
+		priority = journal.PriErr
+	}
+
+	event := eventlog.Event{
+		CheckID:    id,
+		Status:     status,
+		Priority:   priority,
+		DurationMS: duration.Milliseconds(),
+		Timestamp:  time.Now(),
+		Message:    message,
+	}
+
+	if err := getEventsLogger().Log(event); err != nil {
+		logrus.Warnf("unable to log check event for %q: %s", id, err)
+	}
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&eventsLoggerKind, "events-logger", "none",
+		"Where to emit structured check results: file, journald or none.")
+	RootCmd.PersistentFlags().StringVar(&eventsLogPath, "events-log-path", "/var/log/dcos-checks/events.json",
+		"Path to the JSON events logfile when --events-logger=file.")
+}
@@ -15,13 +15,17 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
+	"sort"
 	"strconv"
-	"syscall"
+	"strings"
 
+	"github.com/gupta-ak/dcos-checks/pkg/fscheck"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -33,6 +37,14 @@ const (
 
 	// systemdJournalGroup is a linux system group.
 	systemdJournalGroup = "systemd-journal"
+
+	// journaldConfPath is where systemd-journald reads its main configuration from.
+	journaldConfPath = "/etc/systemd/journald.conf"
+	// journaldConfDropinDir holds journald.conf.d/*.conf drop-ins, which override journaldConfPath.
+	journaldConfDropinDir = "/etc/systemd/journald.conf.d"
+
+	// storageAuto is systemd's own default for Storage= when journald.conf does not set it.
+	storageAuto = "auto"
 )
 
 var (
@@ -40,16 +52,14 @@ var (
 	// journald will write to /run/log/journal in a nonpersistent way.
 	systemJournalPaths = []string{"/var/log/journal", "/run/log/journal"}
 
-	userJournalPath string
+	userJournalPath          string
+	requireJournalPersistent bool
 )
 
-type (
-	grp struct {
-		id   uint32
-		name string
-	}
-	checkDirectoryFn func(string, uint32, map[string]uint32) error
-)
+type grp struct {
+	id   uint32
+	name string
+}
 
 func (g grp) gid() (uint32, error) {
 	if g.name != "" {
@@ -73,40 +83,95 @@ func (g grp) gid() (uint32, error) {
 type JournalCheck struct {
 	Path string
 
+	// Persistent is true when Path is the persistent /var/log/journal location rather than
+	// the volatile /run/log/journal one.
+	Persistent bool
+	// Storage is the effective Storage= setting read from journald.conf.
+	Storage string
+	// RequirePersistent fails the check when only volatile storage is active.
+	RequirePersistent bool
+
 	lookupGroup grp
-	checkBits   map[string]uint32
+}
 
-	checkDirFn checkDirectoryFn
+func storageLabel(persistent bool) string {
+	if persistent {
+		return "persistent"
+	}
+	return "volatile"
 }
 
-func (j *JournalCheck) checkDirectory(path string, group uint32, bits map[string]uint32) error {
-	dirStat, err := os.Stat(path)
-	if err != nil {
-		return err
+// readJournaldConf parses journald.conf and any journald.conf.d/*.conf drop-ins, returning the
+// merged [Journal] section key/value pairs. Drop-ins are applied in lexical order and override
+// values set by confPath, matching systemd's own config precedence.
+func readJournaldConf(confPath, dropinDir string) (map[string]string, error) {
+	conf := make(map[string]string)
+
+	paths := []string{confPath}
+	if dropins, err := filepath.Glob(filepath.Join(dropinDir, "*.conf")); err == nil {
+		sort.Strings(dropins)
+		paths = append(paths, dropins...)
 	}
 
-	helpMsg := fmt.Sprintf("\nTry to run: systemd-tmpfiles --create --prefix %s", path)
+	for _, p := range paths {
+		if err := mergeJournaldConfFile(p, conf); err != nil {
+			return nil, err
+		}
+	}
 
-	perm := dirStat.Mode().Perm()
-	logrus.Debugf("folder %s full permissions: %s", path, perm)
+	return conf, nil
+}
 
-	for description, bit := range bits {
-		if uint32(perm)&bit == 0 {
-			return errors.Errorf("directory %s has wrong permissions: %s bit must be set. \n%s",
-				path, description, helpMsg)
+func mergeJournaldConfFile(path string, conf map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return err
 	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		if section != "Journal" {
+			continue
+		}
 
-	stat, ok := dirStat.Sys().(*syscall.Stat_t)
-	if !ok {
-		return errors.New("unable to type assert to syscall.Stat_t")
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		conf[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
 	}
-	if stat.Gid != group {
-		return errors.Errorf("directory %s must be in group with Gid %d.%s", path, group, helpMsg)
+
+	return scanner.Err()
+}
+
+// effectiveStorage returns the Storage= setting from a parsed journald.conf, defaulting to
+// systemd's own "auto" default when it is unset.
+func effectiveStorage(conf map[string]string) string {
+	if storage, ok := conf["Storage"]; ok && storage != "" {
+		return storage
 	}
-	logrus.Debug("directory is in the right group")
+	return storageAuto
+}
 
-	return nil
+// reconcilePersistent folds the effective Storage= setting into the directory-based guess.
+// varLogExists alone isn't enough: with Storage=volatile, journald writes only to
+// /run/log/journal even when /var/log/journal is present, and Storage=none keeps no logs at
+// all, so neither can be reported as persistent regardless of what exists on disk.
+func reconcilePersistent(varLogExists bool, storage string) bool {
+	return varLogExists && storage != "volatile" && storage != "none"
 }
 
 // ID returns a unique check identifier.
@@ -120,38 +185,49 @@ func (j *JournalCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, in
 		return "", statusUnknown, errors.New("journald path is not set")
 	}
 
-	var err error
 	gid, err := j.lookupGroup.gid()
 	if err != nil {
 		return "", 0, err
 	}
 
-	err = j.checkDirFn(j.Path, gid, j.checkBits)
-	if err != nil {
-		return "", statusUnknown, err
+	policy := fscheck.DirectoryPolicy{
+		RequiredGIDs:     map[uint32]bool{gid: true},
+		RequiredModeBits: groupReadBit | groupExecBit,
+	}
+
+	if violations := policy.Verify(j.Path); len(violations) > 0 {
+		msgs := make([]string, 0, len(violations))
+		for _, v := range violations {
+			msgs = append(msgs, v.Error())
+		}
+		return "", statusUnknown, errors.Errorf("%s\nTry to run: systemd-tmpfiles --create --prefix %s",
+			strings.Join(msgs, "\n"), j.Path)
+	}
+	logrus.Debug("directory is in the right group and has the right permissions")
+
+	if j.RequirePersistent && !j.Persistent {
+		return "", statusWarning, errors.Errorf(
+			"journal storage is only %s (Storage=%s configured), but persistent storage was required.\n"+
+				"Try to run: systemd-tmpfiles --create --prefix %s",
+			storageLabel(j.Persistent), j.Storage, systemJournalPaths[0])
 	}
 
-	return fmt.Sprintf("directory %s has the group owner `systemd-journal` and group permissons r-x", j.Path),
+	return fmt.Sprintf("directory %s has the group owner `systemd-journal` and group permissons r-x; "+
+			"journal storage is %s (Storage=%s configured)", j.Path, storageLabel(j.Persistent), j.Storage),
 		statusOK, nil
 }
 
 // NewJournalCheck returns an initialized instance of JournalCheck.
-func NewJournalCheck(p string) DCOSChecker {
-	j := &JournalCheck{
-		Path: p,
+func NewJournalCheck(p string, persistent bool, storage string, requirePersistent bool) DCOSChecker {
+	return &JournalCheck{
+		Path:              p,
+		Persistent:        persistent,
+		Storage:           storage,
+		RequirePersistent: requirePersistent,
 		lookupGroup: grp{
 			name: systemdJournalGroup,
 		},
-
-		checkBits: map[string]uint32{
-			"group r--": groupReadBit,
-			"group --x": groupExecBit,
-		},
 	}
-
-	j.checkDirFn = j.checkDirectory
-
-	return j
 }
 
 // journaldCmd represents the journald command
@@ -163,32 +239,52 @@ var journaldCmd = &cobra.Command{
 If a user does not set the --path parameter, check will try to use default locations:
  - /var/log/journal
  - /run/log/journal
+
+The check also reports whether the active journal is persistent (/var/log/journal) or
+volatile (/run/log/journal), based on the effective Storage= setting in journald.conf. When
+Storage=auto (the default) and /var/log/journal is missing, journald silently falls back to
+volatile storage and logs are lost across reboots; pass --require-persistent to fail the check
+in that case.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := readJournaldConf(journaldConfPath, journaldConfDropinDir)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		storage := effectiveStorage(conf)
+
+		var varLogExists bool
 		if userJournalPath == "" {
 			var err error
-			userJournalPath, err = getJournalPath(systemJournalPaths)
+			userJournalPath, varLogExists, err = getJournalPath(systemJournalPaths)
 			if err != nil {
 				logrus.Fatal(err)
 			}
+		} else {
+			varLogExists = userJournalPath == systemJournalPaths[0]
 		}
 
-		RunCheck(context.TODO(), NewJournalCheck(userJournalPath))
+		RunCheck(context.TODO(), withEventLogging(NewJournalCheck(userJournalPath,
+			reconcilePersistent(varLogExists, storage), storage, requireJournalPersistent)))
 	},
 }
 
-func getJournalPath(paths []string) (string, error) {
-	for _, p := range paths {
+// getJournalPath returns the first existing path out of paths, along with whether it is the
+// persistent location (paths[0], by convention /var/log/journal).
+func getJournalPath(paths []string) (string, bool, error) {
+	for i, p := range paths {
 		if _, err := os.Stat(p); err == nil {
-			return p, nil
+			return p, i == 0, nil
 		}
 	}
 
-	return "", errors.Errorf("journal paths %s do not exist", paths)
+	return "", false, errors.Errorf("journal paths %s do not exist", paths)
 }
 
 func init() {
 	RootCmd.AddCommand(journaldCmd)
 	journaldCmd.Flags().StringVarP(&userJournalPath, "path", "p", "",
 		"Set a path to systemd journal binary log directory.")
+	journaldCmd.Flags().BoolVar(&requireJournalPersistent, "require-persistent", false,
+		"Fail the check if only volatile (/run/log/journal) storage is active.")
 }
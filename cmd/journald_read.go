@@ -0,0 +1,177 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/go-systemd/sdjournal"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// requiredJournalFields must be present on the newest matching entry for the journal to be
+// considered readable.
+var requiredJournalFields = []string{"MESSAGE", "_HOSTNAME", "PRIORITY"}
+
+var (
+	journalReadUnits   []string
+	journalReadMaxAge  time.Duration
+	journalReadTimeout time.Duration
+)
+
+// journalReader abstracts the subset of *sdjournal.Journal used by JournalReadCheck so tests
+// can stub it out without a live journal.
+type journalReader interface {
+	AddMatch(match string) error
+	SeekTail() error
+	Previous() (uint64, error)
+	GetEntry() (*sdjournal.JournalEntry, error)
+	Close() error
+}
+
+func newJournalReader() (journalReader, error) {
+	return sdjournal.NewJournal()
+}
+
+// JournalReadCheck validates that the systemd journal is actually being written to and can be
+// read back, as opposed to JournalCheck which only validates directory permissions.
+type JournalReadCheck struct {
+	Units   []string
+	MaxAge  time.Duration
+	Timeout time.Duration
+
+	newReader func() (journalReader, error)
+}
+
+// ID returns a unique check identifier.
+func (j *JournalReadCheck) ID() string {
+	return "systemd journal read check"
+}
+
+type previousResult struct {
+	n   uint64
+	err error
+}
+
+// Run the journal read check.
+func (j *JournalReadCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
+	reader, err := j.newReader()
+	if err != nil {
+		return "", statusUnknown, errors.Wrap(err, "unable to open journal")
+	}
+
+	for _, unit := range j.Units {
+		match := sdjournal.Match{Field: "_SYSTEMD_UNIT", Value: unit}
+		if err := reader.AddMatch(match.String()); err != nil {
+			return "", statusUnknown, errors.Wrapf(err, "unable to add match for unit %s", unit)
+		}
+	}
+
+	if err := reader.SeekTail(); err != nil {
+		return "", statusUnknown, errors.Wrap(err, "unable to seek to journal tail")
+	}
+
+	resultCh := make(chan previousResult, 1)
+	go func() {
+		n, err := reader.Previous()
+		resultCh <- previousResult{n, err}
+	}()
+
+	var res previousResult
+	select {
+	case <-ctx.Done():
+		// reader.Previous() is still running in the goroutine above; sdjournal handles aren't
+		// safe for concurrent use, so closing here would race with it. Let a detached goroutine
+		// wait for Previous() to actually finish before closing.
+		go func() { <-resultCh; reader.Close() }()
+		return "", statusUnknown, ctx.Err()
+	case <-time.After(j.Timeout):
+		go func() { <-resultCh; reader.Close() }()
+		return "", statusWarning, errors.Errorf(
+			"journal cursor did not advance for units %v within %s; journal may not be receiving writes",
+			j.Units, j.Timeout)
+	case res = <-resultCh:
+		defer reader.Close()
+	}
+
+	if res.err != nil {
+		return "", statusUnknown, errors.Wrap(res.err, "unable to advance journal cursor")
+	}
+	if res.n == 0 {
+		return "", statusWarning, errors.Errorf(
+			"no journal entries found for units %v", j.Units)
+	}
+
+	entry, err := reader.GetEntry()
+	if err != nil {
+		return "", statusUnknown, errors.Wrap(err, "unable to read journal entry")
+	}
+
+	for _, field := range requiredJournalFields {
+		if _, ok := entry.Fields[field]; !ok {
+			return "", statusWarning, errors.Errorf("newest journal entry is missing required field %q", field)
+		}
+	}
+
+	age := time.Since(time.Unix(0, int64(entry.RealtimeTimestamp)*int64(time.Microsecond)))
+	if age > j.MaxAge {
+		return "", statusWarning, errors.Errorf(
+			"newest journal entry for units %v is %s old, older than --max-age (%s)", j.Units, age, j.MaxAge)
+	}
+
+	logrus.Debugf("newest matching journal entry is %s old", age)
+	return fmt.Sprintf("journal is readable; newest matching entry is %s old", age), statusOK, nil
+}
+
+// NewJournalReadCheck returns an initialized instance of JournalReadCheck.
+func NewJournalReadCheck(units []string, maxAge, timeout time.Duration) DCOSChecker {
+	return &JournalReadCheck{
+		Units:     units,
+		MaxAge:    maxAge,
+		Timeout:   timeout,
+		newReader: newJournalReader,
+	}
+}
+
+// journaldReadCmd represents the journald-read command
+var journaldReadCmd = &cobra.Command{
+	Use:   "journald-read",
+	Short: "Check if the journal is actively being written to and can be read",
+	Long: `Check that the systemd journal can actually be opened and read, rather than just
+checking directory permissions like the journald command does.
+
+The check seeks to the tail of the journal, filtered to the given unit names, and verifies
+that an entry can be read with the MESSAGE, _HOSTNAME and PRIORITY fields present and that
+is no older than --max-age.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunCheck(context.TODO(), withEventLogging(NewJournalReadCheck(journalReadUnits, journalReadMaxAge, journalReadTimeout)))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(journaldReadCmd)
+	journaldReadCmd.Flags().StringSliceVarP(&journalReadUnits, "unit", "u",
+		[]string{"dcos-mesos-master.service", "dcos-mesos-slave.service", "dcos-marathon.service"},
+		"Set the systemd units to filter journal entries by. Can be repeated.")
+	journaldReadCmd.Flags().DurationVar(&journalReadMaxAge, "max-age", 10*time.Minute,
+		"Maximum age allowed for the newest matching journal entry.")
+	journaldReadCmd.Flags().DurationVar(&journalReadTimeout, "timeout", 5*time.Second,
+		"Maximum time to wait for the journal cursor to advance.")
+}
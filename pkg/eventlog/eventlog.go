@@ -0,0 +1,103 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventlog lets DCOSChecker implementations emit their result as a structured event,
+// so operators can track check history (e.g. with journalctl or a log shipper) instead of
+// scraping check stdout.
+package eventlog
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/coreos/go-systemd/journal"
+	"github.com/pkg/errors"
+)
+
+// Event describes the outcome of a single check run.
+type Event struct {
+	CheckID    string
+	Status     int
+	Priority   journal.Priority
+	DurationMS int64
+	Timestamp  time.Time
+	Message    string
+}
+
+// Logger persists a single Event.
+type Logger interface {
+	Log(Event) error
+}
+
+// NewLogger returns the Logger implementation named by kind, one of "file", "journald" or
+// "none". path is only used by the file logger.
+func NewLogger(kind, path string) (Logger, error) {
+	switch kind {
+	case "file":
+		return newFileLogger(path)
+	case "journald":
+		return JournaldLogger{}, nil
+	case "none", "":
+		return NoopLogger{}, nil
+	default:
+		return nil, errors.Errorf("unknown events logger %q, must be file, journald or none", kind)
+	}
+}
+
+// NoopLogger discards every event. It is the default when no --events-logger is configured.
+type NoopLogger struct{}
+
+// Log implements Logger.
+func (NoopLogger) Log(Event) error { return nil }
+
+// FileLogger appends each event as a JSON line to a logfile.
+type FileLogger struct {
+	path string
+}
+
+func newFileLogger(path string) (Logger, error) {
+	if path == "" {
+		return nil, errors.New("file events logger requires a non-empty path")
+	}
+	return &FileLogger{path: path}, nil
+}
+
+// Log implements Logger.
+func (f *FileLogger) Log(e Event) error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open events logfile %s", f.path)
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(e)
+}
+
+// JournaldLogger sends each event to the systemd journal with DCOS_CHECK_* fields, so it can
+// be queried with e.g. `journalctl DCOS_CHECK_ID=<id>`.
+type JournaldLogger struct{}
+
+// Log implements Logger.
+func (JournaldLogger) Log(e Event) error {
+	fields := map[string]string{
+		"DCOS_CHECK_ID":          e.CheckID,
+		"DCOS_CHECK_STATUS":      strconv.Itoa(e.Status),
+		"DCOS_CHECK_DURATION_MS": strconv.FormatInt(e.DurationMS, 10),
+		"DCOS_CHECK_TIMESTAMP":   e.Timestamp.Format(time.RFC3339),
+	}
+
+	return journal.Send(e.Message, e.Priority, fields)
+}
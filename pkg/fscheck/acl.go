@@ -0,0 +1,102 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fscheck
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// selinuxXattr is the xattr name the kernel stores a path's SELinux context under.
+const selinuxXattr = "security.selinux"
+
+func (p DirectoryPolicy) verifyACLs(path string) []Violation {
+	if len(p.RequiredACLEntries) == 0 {
+		return nil
+	}
+
+	// POSIX ACLs are easiest to read reliably via getfacl rather than decoding the binary
+	// system.posix_acl_access xattr ourselves.
+	out, err := exec.Command("getfacl", "--omit-header", path).Output()
+	if err != nil {
+		return []Violation{{Path: path, Reason: fmt.Sprintf("unable to read ACL (is getfacl installed?): %s", err)}}
+	}
+
+	entries := make(map[string]bool)
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			entries[line] = true
+		}
+	}
+
+	var violations []Violation
+	for _, want := range p.RequiredACLEntries {
+		if !entries[want] {
+			violations = append(violations, Violation{Path: path, Reason: fmt.Sprintf("missing required ACL entry %q", want)})
+		}
+	}
+
+	return violations
+}
+
+func (p DirectoryPolicy) verifySELinuxLabel(path string) []Violation {
+	if p.RequiredSELinuxLabel == "" {
+		return nil
+	}
+
+	label, err := getxattr(path, selinuxXattr)
+	if err != nil {
+		return []Violation{{Path: path, Reason: fmt.Sprintf("unable to read SELinux label: %s", err)}}
+	}
+
+	label = strings.TrimRight(label, "\x00")
+	if label != p.RequiredSELinuxLabel {
+		return []Violation{{Path: path, Reason: fmt.Sprintf("SELinux label is %q, want %q", label, p.RequiredSELinuxLabel)}}
+	}
+
+	return nil
+}
+
+func (p DirectoryPolicy) verifyXattrs(path string) []Violation {
+	if len(p.RequiredXattrs) == 0 {
+		return nil
+	}
+
+	var violations []Violation
+	for name, want := range p.RequiredXattrs {
+		got, err := getxattr(path, name)
+		if err != nil {
+			violations = append(violations, Violation{Path: path, Reason: fmt.Sprintf("missing required xattr %s: %s", name, err)})
+			continue
+		}
+		if want != "" && got != want {
+			violations = append(violations, Violation{Path: path, Reason: fmt.Sprintf("xattr %s is %q, want %q", name, got, want)})
+		}
+	}
+
+	return violations
+}
+
+func getxattr(path, name string) (string, error) {
+	buf := make([]byte, 256)
+	n, err := unix.Getxattr(path, name, buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
@@ -0,0 +1,190 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fscheck is a reusable filesystem-integrity policy engine. Callers declare the
+// ownership, permission, ACL, SELinux and xattr requirements for a path once as a
+// DirectoryPolicy, then Verify it to get back the list of Violations. It was pulled out of the
+// cmd package's journald check so other checks (e.g. for /var/lib/dcos, CNI config dirs or
+// secret drop-ins) can reuse the same engine instead of hand-rolling a new Go check per path.
+package fscheck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	groupExecBit = 1 << 3
+	otherExecBit = 1 << 0
+)
+
+// Violation describes a single way a path failed to satisfy a DirectoryPolicy.
+type Violation struct {
+	Path   string
+	Reason string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Reason)
+}
+
+// DirectoryPolicy declares the filesystem-integrity requirements for a directory. The zero
+// value checks nothing, so callers only need to set the fields they care about.
+type DirectoryPolicy struct {
+	// RequiredUID, when non-nil, is the uid the directory must be owned by.
+	RequiredUID *uint32
+	// RequiredGIDs, when non-empty, is the set of gids the directory's group may be in.
+	RequiredGIDs map[uint32]bool
+
+	// RequiredModeBits must all be set on the directory's permission bits.
+	RequiredModeBits os.FileMode
+	// ForbiddenModeBits must all be clear on the directory's permission bits.
+	ForbiddenModeBits os.FileMode
+
+	// RequiredACLEntries, when set, are POSIX ACL entries (e.g. "group:systemd-journal:r-x")
+	// that must appear verbatim in `getfacl`'s output.
+	RequiredACLEntries []string
+	// RequiredSELinuxLabel, when set, is the SELinux context the directory must carry.
+	RequiredSELinuxLabel string
+	// RequiredXattrs, when set, are extended attribute names the directory must carry. A
+	// non-empty value also requires the attribute's content to match exactly.
+	RequiredXattrs map[string]string
+
+	// CheckParentTraversal verifies that every parent directory grants group or other
+	// execute, since a missing x bit anywhere up the tree blocks traversal even when the
+	// directory itself is correctly configured.
+	CheckParentTraversal bool
+	// Recursive applies the policy to every subdirectory, not just the path passed to Verify.
+	Recursive bool
+
+	// statFn is overridable in tests so they don't need real chown/chmod syscalls.
+	statFn func(string) (os.FileInfo, error)
+}
+
+func (p DirectoryPolicy) stat(path string) (os.FileInfo, error) {
+	if p.statFn != nil {
+		return p.statFn(path)
+	}
+	return os.Stat(path)
+}
+
+// Verify evaluates the policy against path and returns every Violation found. A nil result
+// means the policy is satisfied.
+func (p DirectoryPolicy) Verify(path string) []Violation {
+	var violations []Violation
+
+	violations = append(violations, p.verifyPath(path)...)
+
+	if p.CheckParentTraversal {
+		violations = append(violations, p.verifyParentTraversal(path)...)
+	}
+
+	if p.Recursive {
+		_ = filepath.Walk(path, func(sub string, info os.FileInfo, err error) error {
+			if err != nil || sub == path || !info.IsDir() {
+				return nil
+			}
+			violations = append(violations, p.verifyPath(sub)...)
+			return nil
+		})
+	}
+
+	return violations
+}
+
+func (p DirectoryPolicy) verifyPath(path string) []Violation {
+	var violations []Violation
+
+	info, err := p.stat(path)
+	if err != nil {
+		return []Violation{{Path: path, Reason: err.Error()}}
+	}
+
+	perm := info.Mode().Perm()
+	if p.RequiredModeBits != 0 && perm&p.RequiredModeBits != p.RequiredModeBits {
+		violations = append(violations, Violation{
+			Path:   path,
+			Reason: fmt.Sprintf("missing required mode bits %s, has %s", p.RequiredModeBits, perm),
+		})
+	}
+	if p.ForbiddenModeBits != 0 && perm&p.ForbiddenModeBits != 0 {
+		violations = append(violations, Violation{
+			Path:   path,
+			Reason: fmt.Sprintf("has forbidden mode bits %s, has %s", p.ForbiddenModeBits, perm),
+		})
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return append(violations, Violation{Path: path, Reason: "unable to read uid/gid: not a syscall.Stat_t"})
+	}
+
+	if p.RequiredUID != nil && stat.Uid != *p.RequiredUID {
+		violations = append(violations, Violation{
+			Path:   path,
+			Reason: fmt.Sprintf("owned by uid %d, want %d", stat.Uid, *p.RequiredUID),
+		})
+	}
+	if len(p.RequiredGIDs) > 0 && !p.RequiredGIDs[stat.Gid] {
+		violations = append(violations, Violation{
+			Path:   path,
+			Reason: fmt.Sprintf("owned by gid %d, want one of %v", stat.Gid, gidKeys(p.RequiredGIDs)),
+		})
+	}
+
+	violations = append(violations, p.verifyACLs(path)...)
+	violations = append(violations, p.verifySELinuxLabel(path)...)
+	violations = append(violations, p.verifyXattrs(path)...)
+
+	return violations
+}
+
+func (p DirectoryPolicy) verifyParentTraversal(path string) []Violation {
+	var violations []Violation
+
+	dir := filepath.Dir(path)
+	for {
+		info, err := p.stat(dir)
+		if err != nil {
+			violations = append(violations, Violation{Path: dir, Reason: err.Error()})
+			break
+		}
+
+		perm := info.Mode().Perm()
+		if perm&groupExecBit == 0 && perm&otherExecBit == 0 {
+			violations = append(violations, Violation{
+				Path:   dir,
+				Reason: "directory does not grant group or other execute; blocks traversal",
+			})
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return violations
+}
+
+func gidKeys(gids map[uint32]bool) []uint32 {
+	keys := make([]uint32, 0, len(gids))
+	for gid := range gids {
+		keys = append(keys, gid)
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+// Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fscheck
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeFileInfo lets tests control uid/gid/mode without requiring a real chown, which is only
+// permitted for root.
+type fakeFileInfo struct {
+	mode os.FileMode
+	uid  uint32
+	gid  uint32
+}
+
+func (f fakeFileInfo) Name() string       { return "" }
+func (f fakeFileInfo) Size() int64        { return 0 }
+func (f fakeFileInfo) Mode() os.FileMode  { return f.mode }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeFileInfo) Sys() interface{}   { return &syscall.Stat_t{Uid: f.uid, Gid: f.gid} }
+
+// statFor returns a stat function for infos, and treats any path not explicitly listed as a
+// plain, fully-permissive directory so that tests only need to describe the paths they care
+// about (e.g. ancestors above the ones under test).
+func statFor(infos map[string]fakeFileInfo) func(string) (os.FileInfo, error) {
+	return func(path string) (os.FileInfo, error) {
+		if info, ok := infos[path]; ok {
+			return info, nil
+		}
+		return fakeFileInfo{mode: os.ModeDir | 0755}, nil
+	}
+}
+
+func TestVerifyModeAndOwnership(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+
+	policy := DirectoryPolicy{
+		RequiredGIDs:     map[uint32]bool{42: true},
+		RequiredModeBits: 1<<5 | 1<<3, // group r-x
+	}
+	policy.statFn = statFor(map[string]fakeFileInfo{
+		dir: {mode: os.ModeDir | 0750, uid: 0, gid: 42},
+	})
+
+	if violations := policy.Verify(dir); len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+}
+
+func TestVerifyWrongGroup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+
+	policy := DirectoryPolicy{
+		RequiredGIDs:     map[uint32]bool{42: true},
+		RequiredModeBits: 1<<5 | 1<<3,
+	}
+	policy.statFn = statFor(map[string]fakeFileInfo{
+		dir: {mode: os.ModeDir | 0750, uid: 0, gid: 7},
+	})
+
+	violations := policy.Verify(dir)
+	if len(violations) != 1 {
+		t.Fatalf("expected exactly one violation, got %v", violations)
+	}
+}
+
+func TestVerifyMissingModeBits(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+
+	policy := DirectoryPolicy{
+		RequiredModeBits: 1 << 3, // group --x
+	}
+	policy.statFn = statFor(map[string]fakeFileInfo{
+		dir: {mode: os.ModeDir | 0640, uid: 0, gid: 0},
+	})
+
+	violations := policy.Verify(dir)
+	if len(violations) != 1 {
+		t.Fatalf("expected exactly one violation, got %v", violations)
+	}
+}
+
+func TestVerifyParentTraversal(t *testing.T) {
+	root := t.TempDir()
+	blocked := filepath.Join(root, "blocked")
+	dir := filepath.Join(blocked, "journal")
+
+	policy := DirectoryPolicy{CheckParentTraversal: true}
+	policy.statFn = statFor(map[string]fakeFileInfo{
+		dir:     {mode: os.ModeDir | 0750, uid: 0, gid: 0},
+		blocked: {mode: os.ModeDir | 0700, uid: 0, gid: 0}, // no group/other exec
+		root:    {mode: os.ModeDir | 0755, uid: 0, gid: 0},
+	})
+
+	violations := policy.Verify(dir)
+	if len(violations) != 1 {
+		t.Fatalf("expected exactly one traversal violation, got %v", violations)
+	}
+}